@@ -23,6 +23,10 @@ module Api
       render json: { error: e.message }, status: :unprocessable_entity
     end
 
+    rescue_from VncManager::Error, TerminalManager::Error do |e|
+      render json: { error: e.message }, status: :unprocessable_entity
+    end
+
     rescue_from CaddyCertificateAuthority::Error do |e|
       Rails.logger.error("CaddyCertificateAuthority: #{e.message}")
       render json: { error: e.message }, status: :service_unavailable
@@ -35,5 +39,11 @@ module Api
     private
 
     def pundit_user = current_user
+
+    # Sandbox listings only include what a scoped token covers.
+    def policy_scope(scope, **options)
+      relation = super
+      scope == Sandbox && current_api_token ? current_api_token.restrict(relation) : relation
+    end
   end
 end
@@ -0,0 +1,25 @@
+module Api
+  # Server-Sent Events stream of SandboxEvent payloads for the current
+  # user's sandboxes, narrowed to those a scoped token covers. Optional
+  # project and sandbox params filter by project name and sandbox name.
+  # SandboxEventStream serves the stream off the request thread.
+  class EventsController < BaseController
+    def index
+      authorize Sandbox, :index?
+
+      unless request.env["rack.hijack?"]
+        return render json: { error: "This server cannot stream events" }, status: :not_implemented
+      end
+
+      stream = SandboxEventStream.new(current_user.id, project: params[:project], sandbox: params[:sandbox],
+                                      token: current_api_token)
+      unless stream.serve(request.env)
+        response.headers["Retry-After"] = "30"
+        return render json: { error: "Too many event streams are open; try again later" }, status: :service_unavailable
+      end
+
+      # Puma ignores the response of a hijacked request.
+      head :ok
+    end
+  end
+end
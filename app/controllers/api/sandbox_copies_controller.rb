@@ -0,0 +1,40 @@
+module Api
+  # Copies a path from one of the user's sandboxes into another. create
+  # starts a SandboxCopyJob and answers 202 with the copy; clients poll show
+  # for its bytes and total until its status is done or failed.
+  class SandboxCopiesController < BaseController
+    def create
+      source = policy_scope(Sandbox).includes(:user).find(params[:id])
+      authorize source, :copy?
+      destination = policy_scope(Sandbox).includes(:user).find(params.require(:to))
+      authorize destination, :copy?
+
+      unless source.status == "running" && destination.status == "running"
+        return render json: { error: "Sandbox is not running" }, status: :conflict
+      end
+
+      copy = SandboxCopy.create!(
+        user: current_user,
+        source:,
+        destination:,
+        source_path: params.require(:source_path),
+        destination_path: params.require(:destination_path),
+        recursive: ActiveModel::Type::Boolean.new.cast(params[:recursive]) || false
+      )
+      SandboxCopyJob.perform_later(copy_id: copy.id)
+      render json: copy_json(copy), status: :accepted
+    end
+
+    def show
+      copy = SandboxCopy.where(user: current_user).find(params[:id])
+      authorize copy.source, :copy?
+      render json: copy_json(copy)
+    end
+
+    private
+
+    def copy_json(copy)
+      { id: copy.id, status: copy.status, bytes: copy.bytes, total: copy.total, error: copy.error }.compact
+    end
+  end
+end
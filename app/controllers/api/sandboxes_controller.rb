@@ -1,6 +1,6 @@
 module Api
   class SandboxesController < BaseController
-    before_action :set_sandbox, only: %i[show update destroy start stop rebuild logs connect snapshot restore tailscale_connect tailscale_disconnect service_start service_stop gcp_oidc_setup gcp_identity]
+    before_action :set_sandbox, only: %i[show update destroy start stop rebuild logs connect snapshot restore tailscale_connect tailscale_disconnect vnc terminal service_start service_stop gcp_oidc_setup gcp_identity]
     before_action :set_archived_sandbox, only: %i[archive_restore purge]
 
     def index
@@ -9,6 +9,28 @@ module Api
       render json: sandboxes.map { |s| sandbox_json(s) }
     end
 
+    # Resolves a [project:]name ref without listing every sandbox.
+    def lookup
+      authorize Sandbox, :index?
+      name = params.require(:name)
+      project = params[:project].presence
+      ref = project ? "#{project}:#{name}" : name
+
+      sandboxes = policy_scope(Sandbox).where(name: name)
+      sandboxes = sandboxes.where(project_name: project) if project
+      sandboxes = sandboxes.includes(:user, :routes).to_a
+
+      case sandboxes.size
+      when 0
+        render json: { error: "Sandbox #{ref.inspect} not found" }, status: :not_found
+      when 1
+        render json: sandbox_json(sandboxes.first)
+      else
+        candidates = sandboxes.sort_by(&:display_name).map { |s| "#{s.display_name} (id #{s.id})" }
+        render json: { error: "Sandbox #{ref.inspect} is ambiguous: #{candidates.join(", ")}" }, status: :conflict
+      end
+    end
+
     def archived_index
       authorize Sandbox
       sandboxes = if current_user.admin?
@@ -137,6 +159,7 @@ module Api
         return
       end
 
+      @sandbox.start_job("starting")
       SandboxStartJob.perform_later(sandbox_id: @sandbox.id)
       render json: sandbox_json(@sandbox.reload)
     end
@@ -147,6 +170,7 @@ module Api
         return
       end
 
+      @sandbox.start_job("stopping")
       SandboxStopJob.perform_later(sandbox_id: @sandbox.id)
       render json: sandbox_json(@sandbox.reload)
     end
@@ -157,6 +181,7 @@ module Api
         return
       end
 
+      @sandbox.start_job("rebuilding")
       SandboxRebuildJob.perform_later(sandbox_id: @sandbox.id)
       render json: sandbox_json(@sandbox.reload)
     end
@@ -252,6 +277,21 @@ module Api
       render json: sandbox_json(@sandbox.reload)
     end
 
+    # Opens the web VNC viewer for the sandbox and returns its URL, which
+    # the browser authenticates with the user's web session.
+    def vnc
+      path = VncManager.new.open(sandbox: @sandbox)
+      base = ENV["SANDCASTLE_VNC_URL"] || ENV["SANDCASTLE_TERMINAL_URL"] || request.base_url
+      render json: { url: "#{base}#{path}" }
+    end
+
+    # Opens the web terminal for the sandbox and returns the page's URL.
+    def terminal
+      type = params[:type].presence_in(%w[tmux shell]) || "tmux"
+      TerminalManager.new.open(sandbox: @sandbox, type: type)
+      render json: { url: terminal_show_url(@sandbox, type) }
+    end
+
     private
 
     def set_sandbox
@@ -9,8 +9,27 @@ module Api
     end
 
     def create
-      token, raw = ApiToken.generate_for(@password_user, name: params.require(:name))
+      token, raw = ApiToken.generate_for(@password_user,
+        name: params.require(:name),
+        expires_in: expires_in_param,
+        scope: ApiToken.resolve_scope(@password_user, params[:scope]),
+        description: params[:description].presence)
       render json: token_json(token).merge(raw_token: raw), status: :created
+    rescue ApiToken::InvalidScope, ArgumentError => e
+      render json: { error: e.message }, status: :unprocessable_entity
+    end
+
+    # Issues a replacement for the token and lets the old one keep working
+    # for grace_period seconds (default one hour) so deployments can switch.
+    def rotate
+      token = current_user.api_tokens.active.find(params[:id])
+      grace_period = params.fetch(:grace_period, 1.hour.to_i).to_i
+      if grace_period.negative?
+        return render json: { error: "grace_period must not be negative" }, status: :unprocessable_entity
+      end
+
+      replacement, raw = token.rotate!(grace_period: grace_period.seconds)
+      render json: token_json(replacement).merge(raw_token: raw, replaces: token_json(token.reload)), status: :created
     end
 
     def destroy
@@ -31,10 +50,20 @@ module Api
       end
     end
 
+    def expires_in_param
+      return nil if params[:expires_in].blank?
+
+      seconds = Integer(params[:expires_in].to_s)
+      raise ArgumentError, "expires_in must be a positive number of seconds" unless seconds.positive?
+      seconds.seconds
+    end
+
     def token_json(token)
       {
         id: token.id,
         name: token.name,
+        description: token.description,
+        scope: token.scope,
         prefix: token.prefix,
         masked_token: token.masked_token,
         last_used_at: token.last_used_at,
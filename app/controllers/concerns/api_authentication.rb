@@ -3,6 +3,7 @@ module ApiAuthentication
 
   included do
     before_action :authenticate_api_token!
+    before_action :enforce_token_scope!
     attr_reader :current_api_token
   end
 
@@ -17,6 +18,58 @@ module ApiAuthentication
     end
   end
 
+  # Read-only tokens may only read. Sandbox- and project-scoped tokens may
+  # only reach the sandboxes they cover; listings are narrowed in
+  # policy_scope.
+  def enforce_token_scope!
+    token = current_api_token
+    return if token.nil? || token.full_access?
+    return if token_scope_permits_request?(token)
+
+    render json: { error: "Token scope #{token.scope} does not allow this request" }, status: :forbidden
+  end
+
+  def token_scope_permits_request?(token)
+    return request.get? || request.head? if token.read_only?
+
+    case controller_name
+    when "sandboxes"
+      case action_name
+      when "index", "lookup" then true
+      when "archived_index" then false
+      when "create"
+        token.scoped_project.present? &&
+          (params[:project_path].presence || params[:project_name].presence) == token.scoped_project
+      else token_covers_sandbox_id?(token, params[:id])
+      end
+    when "routes", "sandbox_aliases"
+      token_covers_sandbox_id?(token, params[:sandbox_id])
+    when "snapshots"
+      action_name == "create" && token_covers_sandbox_id?(token, params[:sandbox_id])
+    when "sandbox_copies"
+      case action_name
+      when "create"
+        token_covers_sandbox_id?(token, params[:id]) && token_covers_sandbox_id?(token, params[:to])
+      when "show"
+        copy = SandboxCopy.find_by(id: params[:id])
+        copy.present? && token_covers_sandbox_id?(token, copy.source_id) &&
+          token_covers_sandbox_id?(token, copy.destination_id)
+      else false
+      end
+    when "tokens"
+      action_name == "index" || params[:id].to_s == token.id.to_s
+    when "events", "infos", "trust"
+      true
+    else
+      false
+    end
+  end
+
+  def token_covers_sandbox_id?(token, id)
+    sandbox = Sandbox.find_by(id: id)
+    sandbox.present? && token.covers_sandbox?(sandbox.id, sandbox.project_name)
+  end
+
   def current_user
     @current_api_token&.user
   end
@@ -38,12 +38,18 @@ class VncController < ApplicationController
   public
 
   # Called by Traefik forwardAuth. Returns 200 to allow, or redirects to
-  # login (which Traefik passes through to the browser).
+  # login (which Traefik passes through to the browser). The CLI's VNC
+  # bridge sends its API token instead of a session cookie.
   def auth
     forwarded_uri = request.headers["X-Forwarded-Uri"] || ""
     match = forwarded_uri.match(%r{/vnc/(\d+)})
     head(:unauthorized) and return unless match
 
+    if request.headers["Authorization"].present?
+      head(token_allows_vnc?(match[1].to_i) ? :ok : :unauthorized)
+      return
+    end
+
     session_record = find_session_by_cookie
     unless session_record
       # Build the original VNC URL from Traefik's forwarded headers
@@ -62,4 +68,17 @@ class VncController < ApplicationController
 
     head :ok
   end
+
+  private
+
+  # A read-only token may not drive a desktop; a scoped one only reaches
+  # the sandboxes it covers.
+  def token_allows_vnc?(sandbox_id)
+    token = ApiToken.authenticate(request.headers["Authorization"].delete_prefix("Bearer "))
+    sandbox = Sandbox.active.find_by(id: sandbox_id)
+    return false unless token && sandbox && !token.read_only?
+    return false unless token.covers_sandbox?(sandbox.id, sandbox.project_name)
+
+    sandbox.user_id == token.user_id || token.user.admin?
+  end
 end
@@ -0,0 +1,40 @@
+class SandboxCopyJob < ApplicationJob
+  queue_as :default
+
+  PROGRESS_INTERVAL = 1.second
+  # Finished copies are kept this long for clients still polling them.
+  RETENTION = 1.day
+
+  def perform(copy_id:)
+    copy = SandboxCopy.find(copy_id)
+    return unless copy.status == "pending" # Idempotent
+
+    copy.update!(status: "running")
+    reported_at = Time.current
+    copied = SandboxManager.new.copy_between(
+      source: copy.source,
+      destination: copy.destination,
+      source_path: copy.source_path,
+      destination_path: copy.destination_path,
+      recursive: copy.recursive
+    ) do |bytes, total|
+      next if Time.current - reported_at < PROGRESS_INTERVAL
+
+      reported_at = Time.current
+      # Called from the copy's producer thread.
+      SandboxCopy.connection_pool.with_connection do
+        copy.update_columns(bytes:, total:, updated_at: reported_at)
+      end
+    end
+    copy.update!(status: "done", bytes: copied)
+
+  rescue SandboxManager::Error => e
+    copy.update!(status: "failed", error: e.message)
+  rescue => e
+    Rails.logger.error("SandboxCopyJob failed: #{e.message}\n#{e.backtrace.join("\n")}")
+    copy&.update!(status: "failed", error: "Copy failed: #{e.message}")
+    raise
+  ensure
+    SandboxCopy.finished.where(updated_at: ...RETENTION.ago).delete_all
+  end
+end
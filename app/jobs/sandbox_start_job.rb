@@ -3,7 +3,10 @@ class SandboxStartJob < ApplicationJob
 
   def perform(sandbox_id:)
     sandbox = Sandbox.find(sandbox_id)
-    return if sandbox.status == "running" # Idempotent
+    if sandbox.status == "running" # Idempotent
+      sandbox.finish_job
+      return
+    end
 
     begin
       SandboxManager.new.start(sandbox: sandbox)
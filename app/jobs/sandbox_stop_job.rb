@@ -3,7 +3,10 @@ class SandboxStopJob < ApplicationJob
 
   def perform(sandbox_id:)
     sandbox = Sandbox.find(sandbox_id)
-    return if sandbox.status == "stopped" # Idempotent
+    if sandbox.status == "stopped" # Idempotent
+      sandbox.finish_job
+      return
+    end
 
     begin
       SandboxManager.new.stop(sandbox: sandbox)
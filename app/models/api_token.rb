@@ -2,14 +2,18 @@ class ApiToken < ApplicationRecord
   belongs_to :user
 
   PREFIX_LENGTH = 8
+  SCOPE_FORMAT = /\A(read-only|sandbox:\d+|project:\S+)\z/
+
+  class InvalidScope < StandardError; end
 
   validates :name, presence: true
   validates :token_digest, presence: true
   validates :prefix, presence: true, uniqueness: true
+  validates :scope, format: { with: SCOPE_FORMAT }, allow_nil: true
 
   scope :active, -> { where("expires_at IS NULL OR expires_at > ?", Time.current) }
 
-  def self.generate_for(user, name:, expires_in: nil)
+  def self.generate_for(user, name:, expires_in: nil, scope: nil, description: nil)
     raw_secret = SecureRandom.hex(24)
     prefix = "sc_#{SecureRandom.hex(PREFIX_LENGTH / 2)}"
     raw_token = "#{prefix}_#{raw_secret}"
@@ -18,7 +22,9 @@ class ApiToken < ApplicationRecord
       name: name,
       prefix: prefix,
       token_digest: BCrypt::Password.create(raw_secret),
-      expires_at: expires_in ? Time.current + expires_in : nil
+      expires_at: expires_in ? Time.current + expires_in : nil,
+      scope: scope,
+      description: description
     )
 
     [ token, raw_token ]
@@ -41,10 +47,92 @@ class ApiToken < ApplicationRecord
     token
   end
 
+  # Normalizes a requested scope for user. "sandbox:" accepts a sandbox id or
+  # a [project:]name ref and is stored by id so a rename doesn't widen it.
+  def self.resolve_scope(user, value)
+    return nil if value.blank? || value == "full"
+    return value if value == "read-only"
+
+    kind, target = value.split(":", 2)
+    raise InvalidScope, "Invalid scope #{value.inspect}: use read-only, sandbox:<ref> or project:<name>" if target.blank?
+
+    case kind
+    when "project"
+      raise InvalidScope, "Project #{target.inspect} not found" unless user.projects.exists?(name: target)
+      "project:#{target}"
+    when "sandbox"
+      sandboxes = user.sandboxes.active
+      matches = if target.match?(/\A\d+\z/)
+        sandboxes.where(id: target)
+      elsif target.include?(":")
+        project, name = target.split(":", 2)
+        sandboxes.where(project_name: project, name: name)
+      else
+        sandboxes.where(name: target)
+      end.to_a
+      raise InvalidScope, "Sandbox #{target.inspect} not found" if matches.empty?
+      raise InvalidScope, "Sandbox #{target.inspect} is ambiguous; use project:name or the sandbox id" if matches.size > 1
+      "sandbox:#{matches.first.id}"
+    else
+      raise InvalidScope, "Invalid scope #{value.inspect}: use read-only, sandbox:<ref> or project:<name>"
+    end
+  end
+
+  # Issues a replacement with the same name, scope, description and lifetime,
+  # and lets this token expire after grace_period.
+  def rotate!(grace_period:)
+    transaction do
+      lifetime = expires_at && (expires_at - created_at)
+      replacement, raw = self.class.generate_for(user, name: name, expires_in: lifetime, scope: scope, description: description)
+      retire_at = Time.current + grace_period
+      update!(expires_at: retire_at) if expires_at.nil? || expires_at > retire_at
+      [ replacement, raw ]
+    end
+  end
+
   def expired?
     expires_at.present? && expires_at < Time.current
   end
 
+  def full_access?
+    scope.nil?
+  end
+
+  def read_only?
+    scope == "read-only"
+  end
+
+  def scoped_sandbox_id
+    scope.delete_prefix("sandbox:").to_i if scope&.start_with?("sandbox:")
+  end
+
+  def scoped_project
+    scope.delete_prefix("project:") if scope&.start_with?("project:")
+  end
+
+  # Whether the token may act on the sandbox with id in project_name.
+  # Read-only tokens see every sandbox; the request method limits them.
+  def covers_sandbox?(id, project_name)
+    if scoped_sandbox_id
+      id.to_i == scoped_sandbox_id
+    elsif scoped_project
+      project_name == scoped_project
+    else
+      true
+    end
+  end
+
+  # Narrows a Sandbox relation to what the token covers.
+  def restrict(sandboxes)
+    if scoped_sandbox_id
+      sandboxes.where(id: scoped_sandbox_id)
+    elsif scoped_project
+      sandboxes.where(project_name: scoped_project)
+    else
+      sandboxes
+    end
+  end
+
   def masked_token
     "#{prefix}_#{'*' * 12}"
   end
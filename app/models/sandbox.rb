@@ -48,6 +48,11 @@ class Sandbox < ApplicationRecord
   after_update_commit :broadcast_replace_to_dashboard
   after_destroy_commit :broadcast_remove_from_dashboard
 
+  # Lifecycle events for `sandcastle events` (see SandboxEvent)
+  after_create_commit -> { SandboxEvent.publish(self, "created") }
+  after_update_commit :publish_lifecycle_event
+  after_destroy_commit -> { SandboxEvent.publish(self, "destroyed") }
+
   def full_name
     "#{user.name}-#{hostname}"
   end
@@ -193,6 +198,7 @@ class Sandbox < ApplicationRecord
       updated_at: Time.current
     )
     broadcast_replace_to_dashboard
+    SandboxEvent.publish(self, "job_failed")
   end
 
   private
@@ -275,6 +281,11 @@ class Sandbox < ApplicationRecord
     errors.add(:gcp_oidc_config, "must belong to the sandbox owner") if gcp_oidc_config.user_id != user_id
   end
 
+  def publish_lifecycle_event
+    type = SandboxEvent.type_for_update(self)
+    SandboxEvent.publish(self, type) if type
+  end
+
   def broadcast_prepend_to_dashboard
     broadcast_prepend_to(
       [ user, "dashboard" ],
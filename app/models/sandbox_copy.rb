@@ -0,0 +1,14 @@
+# A copy of a path from one sandbox into another, run in the background by
+# SandboxCopyJob. Clients poll it for progress until it is done or failed.
+class SandboxCopy < ApplicationRecord
+  STATUSES = %w[pending running done failed].freeze
+
+  belongs_to :user
+  belongs_to :source, class_name: "Sandbox"
+  belongs_to :destination, class_name: "Sandbox"
+
+  validates :status, inclusion: { in: STATUSES }
+  validates :source_path, :destination_path, presence: true
+
+  scope :finished, -> { where(status: %w[done failed]) }
+end
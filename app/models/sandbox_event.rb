@@ -0,0 +1,55 @@
+# Lifecycle events for a user's sandboxes, streamed to API clients by
+# Api::EventsController. They go out over Action Cable's pub/sub so events
+# raised in a job worker reach the web process holding the stream.
+class SandboxEvent
+  STATUS_TYPES = %w[started stopped archived restored destroyed status_changed].freeze
+
+  def self.channel_for(user_id)
+    "sandbox_events:#{user_id}"
+  end
+
+  def self.publish(sandbox, type)
+    ActionCable.server.broadcast(channel_for(sandbox.user_id), payload(sandbox, type))
+  rescue => e
+    Rails.logger.warn("SandboxEvent.publish: #{e.message}")
+  end
+
+  def self.payload(sandbox, type)
+    {
+      type: type,
+      at: Time.current.iso8601(3),
+      previous_status: STATUS_TYPES.include?(type) ? sandbox.status_previously_was : nil,
+      sandbox: {
+        id: sandbox.id,
+        name: sandbox.name,
+        project_name: sandbox.project_name,
+        status: sandbox.status,
+        job_status: sandbox.job_status,
+        job_error: sandbox.job_error
+      }
+    }.compact
+  end
+
+  # Names the change a committed update made, or nil when nothing a client
+  # would care about changed.
+  def self.type_for_update(sandbox)
+    if sandbox.saved_change_to_status?
+      previous = sandbox.status_previously_was
+      case sandbox.status
+      when "destroyed" then "destroyed"
+      when "archived" then "archived"
+      else
+        return "restored" if previous == "archived"
+        { "running" => "started", "stopped" => "stopped" }.fetch(sandbox.status, "status_changed")
+      end
+    elsif sandbox.saved_change_to_job_status?
+      if sandbox.job_status.present?
+        "job_started"
+      else
+        sandbox.job_error.present? ? "job_failed" : "job_finished"
+      end
+    elsif sandbox.saved_change_to_name? || sandbox.saved_change_to_project_name?
+      "renamed"
+    end
+  end
+end
@@ -13,12 +13,15 @@ class SandboxPolicy < ApplicationPolicy
   def metrics?               = owner_or_admin?
   def card?                  = owner_only?
   def connect?               = owner_only?
+  def copy?                  = owner_only?
   def snapshot?              = owner_only?
   def restore?               = owner_only?
   def archive_restore?       = owner_or_admin?
   def purge?                 = owner_or_admin?
   def tailscale_connect?     = owner_only?
   def tailscale_disconnect?  = owner_only?
+  def vnc?                   = owner_only?
+  def terminal?              = owner_only?
   def gcp_oidc_setup?        = owner_only?
   def gcp_identity?          = owner_only?
   def discover_files?        = owner_only?
@@ -0,0 +1,118 @@
+# Streams one client's SandboxEvent payloads as Server-Sent Events. The
+# request's connection is hijacked and served from a thread of its own, so
+# a listener does not hold one of Puma's few request threads for as long as
+# it stays connected. At most MAX_STREAMS are open per process and each
+# ends after MAX_DURATION, or at the next heartbeat once its token has been
+# revoked, rotated out or has expired; clients reconnect.
+class SandboxEventStream
+  HEARTBEAT_INTERVAL = 15.seconds
+  MAX_DURATION = 1.hour
+  MAX_STREAMS = ENV.fetch("SANDCASTLE_MAX_EVENT_STREAMS", 100).to_i
+
+  HEADERS = [
+    "HTTP/1.1 200 OK",
+    "Content-Type: text/event-stream",
+    "Cache-Control: no-cache",
+    "X-Accel-Buffering: no",
+    "Connection: close"
+  ].join("\r\n").freeze
+
+  @open = 0
+  @lock = Mutex.new
+
+  class << self
+    # Reserves a stream slot; false when MAX_STREAMS are already open.
+    def acquire
+      @lock.synchronize do
+        return false if @open >= MAX_STREAMS
+        @open += 1
+        true
+      end
+    end
+
+    def release
+      @lock.synchronize { @open -= 1 }
+    end
+  end
+
+  # token, when given, limits the stream to the sandboxes its scope covers.
+  def initialize(user_id, project: nil, sandbox: nil, token: nil)
+    @channel = SandboxEvent.channel_for(user_id)
+    @project = project.presence
+    @sandbox = sandbox.presence
+    @token = token
+  end
+
+  # Whether event, a decoded SandboxEvent payload, goes to this client.
+  def matches?(event)
+    sandbox = event["sandbox"] || {}
+    return false if @token && !@token.covers_sandbox?(sandbox["id"], sandbox["project_name"])
+    return false if @project && sandbox["project_name"] != @project
+    return false if @sandbox && sandbox["name"] != @sandbox
+    true
+  end
+
+  # Hijacks the connection of the Rack request env and streams to it from
+  # a new thread. Returns false, leaving the request alone, when too many
+  # streams are open.
+  def serve(env)
+    return false unless self.class.acquire
+
+    begin
+      io = env["rack.hijack"].call
+      Thread.new do
+        stream_to(io)
+      ensure
+        self.class.release
+      end
+    rescue
+      self.class.release
+      raise
+    end
+    true
+  end
+
+  private
+
+  def stream_to(io)
+    queue = Thread::Queue.new
+    callback = ->(message) { queue << message }
+    ActionCable.server.pubsub.subscribe(@channel, callback)
+
+    io.write("#{HEADERS}\r\n\r\nretry: 3000\n: connected\n\n")
+    deadline = monotonic_now + MAX_DURATION
+    next_check = monotonic_now + HEARTBEAT_INTERVAL
+    while (left = deadline - monotonic_now) > 0
+      message = queue.pop(timeout: [ HEARTBEAT_INTERVAL.to_f, left ].min)
+      if monotonic_now >= next_check
+        break unless token_active?
+        next_check = monotonic_now + HEARTBEAT_INTERVAL
+      end
+      if message.nil?
+        io.write(": keepalive\n\n")
+        next
+      end
+
+      event = JSON.parse(message)
+      io.write("event: #{event["type"]}\ndata: #{JSON.generate(event)}\n\n") if matches?(event)
+    end
+  rescue IOError, SystemCallError
+    # Client went away.
+  rescue => e
+    Rails.logger.warn("SandboxEventStream: #{e.class}: #{e.message}")
+  ensure
+    ActionCable.server.pubsub.unsubscribe(@channel, callback) if callback
+    io.close unless io.closed?
+  end
+
+  # Whether the token the stream was opened with still authenticates. A
+  # revoked token is gone, and a rotated one expires once its grace ends.
+  def token_active?
+    return true unless @token
+    ActiveRecord::Base.connection_pool.with_connection { ApiToken.active.exists?(@token.id) }
+  end
+
+  def monotonic_now
+    Process.clock_gettime(Process::CLOCK_MONOTONIC)
+  end
+end
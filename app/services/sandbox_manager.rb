@@ -739,10 +739,165 @@ class SandboxManager
       user: user,
       command: "ssh #{user}@#{ts_ip}",
       tailscale_ip: ts_ip,
-      primary_dns_name: DnsManager.new.hostname_for(sandbox)
+      primary_dns_name: DnsManager.new.hostname_for(sandbox),
+      host_keys: ssh_host_keys(sandbox: sandbox),
+      container_id: sandbox.container_id&.first(12)
     }
   end
 
+  # Public SSH host keys of the sandbox as "<type> <base64>" strings, so
+  # clients can pin them. The keys are generated when a container is
+  # created, so they change exactly when container_id does. Empty when the
+  # container can't be asked.
+  def ssh_host_keys(sandbox:)
+    return [] if sandbox.container_id.blank?
+
+    container = Docker::Container.get(sandbox.container_id)
+    out = container.exec([ "sh", "-c", "cat /etc/ssh/ssh_host_*_key.pub 2>/dev/null" ])
+    stdout = Array(out.first).join
+    stdout.lines.filter_map do |line|
+      type, key = line.split
+      "#{type} #{key}" if type.present? && key.present?
+    end
+  rescue Docker::Error::DockerError, Excon::Error => e
+    Rails.logger.warn("ssh_host_keys: failed for sandbox #{sandbox.id}: #{e.message}")
+    []
+  end
+
+  # Sandbox-to-sandbox copies pipe a tar stream from COPY_PACK_SCRIPT in the
+  # source container into COPY_UNPACK_SCRIPT in the destination. The stream
+  # starts with a "<D|F> <kilobytes> <name>" header line. The CLI runs the
+  # same scripts over SSH when it pipes a copy through the client; its
+  # TestSandboxCopyScriptsMatchTheServer fails when the two copies differ.
+  COPY_PACK_SCRIPT = <<~'SH'.chomp.freeze
+    p=$1
+    case $p in "~") p=$HOME ;; "~/"*) p=$HOME/${p#"~/"} ;; esac
+    if [ -d "$p" ]; then
+      if [ "$2" != 1 ]; then echo "$1 is a directory (use -r)" >&2; exit 1; fi
+      printf 'D %s %s\n' "$(du -sk "$p" 2>/dev/null | cut -f1)" "$(basename "$p")"
+      exec tar -C "$p" -cf - .
+    elif [ -e "$p" ]; then
+      printf 'F %s %s\n' "$(du -sk "$p" 2>/dev/null | cut -f1)" "$(basename "$p")"
+      exec tar -C "$(dirname "$p")" -cf - "$(basename "$p")"
+    fi
+    echo "$1: no such file or directory" >&2
+    exit 1
+  SH
+
+  COPY_UNPACK_SCRIPT = <<~'SH'.chomp.freeze
+    p=$1
+    case $p in "~") p=$HOME ;; "~/"*) p=$HOME/${p#"~/"} ;; esac
+    IFS= read -r header || { echo "nothing to copy" >&2; exit 1; }
+    kind=${header%% *}
+    name=${header#* }
+    name=${name#* }
+    [ -d "$p" ] && p=$p/$name
+    case $kind in
+      D) mkdir -p "$p" && exec tar -C "$p" -xf - ;;
+      F) mkdir -p "$(dirname "$p")" && exec tar -xOf - > "$p" ;;
+    esac
+    echo "unexpected copy header: $header" >&2
+    exit 1
+  SH
+
+  # Streams source_path in source into destination_path in destination
+  # without staging it on the host or in memory. Yields (bytes, total) as data moves;
+  # total is the du estimate in bytes, or nil until it is known.
+  def copy_between(source:, destination:, source_path:, destination_path:, recursive: false)
+    [ source, destination ].each do |sandbox|
+      raise Error, "Sandbox #{sandbox.display_name} is not running" unless sandbox.status == "running" && sandbox.container_id.present?
+    end
+
+    src = Docker::Container.get(source.container_id)
+    dst = Docker::Container.get(destination.container_id)
+    reader, writer = IO.pipe
+    writer.binmode
+    bytes = 0
+    total = nil
+    src_stderr = +""
+
+    producer = Thread.new do
+      status = exec_streaming(
+        src,
+        [ "sh", "-c", COPY_PACK_SCRIPT, "sh", source_path, recursive ? "1" : "0" ],
+        user: source.user.name
+      ) do |stream, chunk|
+        if stream == :stdout
+          if total.nil? && (kb = chunk[/\A[DF] (\d+) /, 1])
+            total = kb.to_i * 1024
+          end
+          writer.write(chunk)
+          bytes += chunk.bytesize
+          yield bytes, total if block_given?
+        else
+          src_stderr << chunk
+        end
+      end
+      status
+    rescue IOError, Errno::EPIPE
+      # The destination stopped reading; its error is reported instead.
+      nil
+    ensure
+      writer.close
+    end
+
+    _, dst_stderr, dst_status = dst.exec(
+      [ "sh", "-c", COPY_UNPACK_SCRIPT, "sh", destination_path ],
+      stdin: reader,
+      user: destination.user.name
+    )
+    reader.close
+    src_status = producer.value
+
+    raise Error, "#{source.display_name}: #{src_stderr.strip.presence || "copy failed"}" if src_status.to_i != 0
+    raise Error, "#{destination.display_name}: #{Array(dst_stderr).join.strip.presence || "copy failed"}" if dst_status.to_i != 0
+    bytes
+  rescue Docker::Error::DockerError => e
+    raise Error, "Copy failed: #{e.message}"
+  ensure
+    reader&.close unless reader&.closed?
+    producer&.join
+  end
+
+  # Runs command in container like Docker::Container#exec, but passes its
+  # output to the block as it arrives without keeping it, and returns the
+  # exit code. docker-api's exec collects every chunk it yields, which for
+  # a copy is the whole tar stream.
+  def exec_streaming(container, command, user:, &block)
+    exec = Docker::Exec.create(
+      {
+        "Container" => container.id,
+        "User" => user,
+        "Cmd" => command,
+        "AttachStdout" => true,
+        "AttachStderr" => true
+      },
+      container.connection
+    )
+
+    # Without a TTY the output is multiplexed into frames with an 8-byte
+    # header: the stream (1 stdout, 2 stderr), three zero bytes and the
+    # big-endian payload size.
+    pending = String.new(encoding: Encoding::BINARY)
+    demux = lambda do |chunk, _remaining, _total|
+      pending << chunk.b
+      while pending.bytesize >= 8
+        stream, size = pending.unpack("CxxxN")
+        break if pending.bytesize < 8 + size
+
+        block.call(stream == 2 ? :stderr : :stdout, pending.byteslice(8, size))
+        pending = pending.byteslice((8 + size)..)
+      end
+    end
+    container.connection.post(
+      "/exec/#{exec.id}/start", nil,
+      body: { "Detach" => false, "Tty" => false }.to_json,
+      headers: { "Content-Type" => "application/json" },
+      response_block: demux
+    )
+    exec.json["ExitCode"]
+  end
+
   HOME_BASELINE_PATH = "/var/sandcastle/home-baseline.txt".freeze
 
   def write_home_baseline(container, user)
@@ -66,11 +66,19 @@ class VncManager
     File.join(DYNAMIC_DIR, "vnc-#{sandbox.id}.yml")
   end
 
+  # Writes the sandbox's Traefik config unless it is already current.
+  # Configs written by older versions are replaced.
   def write_traefik_config(sandbox)
     FileUtils.mkdir_p(DYNAMIC_DIR)
     config_path = traefik_config_path(sandbox)
-    return false if File.exist?(config_path)
+    content = traefik_config(sandbox).to_yaml
+    return false if File.exist?(config_path) && File.read(config_path) == content
 
+    File.write(config_path, content)
+    true
+  end
+
+  def traefik_config(sandbox)
     host = ENV.fetch("SANDCASTLE_HOST", "localhost")
     id = sandbox.id
     sandbox_name = sandbox.full_name
@@ -91,7 +99,7 @@ class VncManager
         "service" => "vnc-#{id}",
         "entryPoints" => [ "websecure" ],
         "tls" => tls_config,
-        "middlewares" => [ "vnc-auth-#{id}", "vnc-stripprefix-#{id}" ],
+        "middlewares" => [ "vnc-auth-#{id}", "vnc-stripcredentials-#{id}", "vnc-stripprefix-#{id}" ],
         "priority" => 100
       }
     }
@@ -104,7 +112,7 @@ class VncManager
       ).except("tls")
     end
 
-    config = {
+    {
       "http" => {
         "routers" => routers,
         "middlewares" => {
@@ -114,6 +122,14 @@ class VncManager
               "trustForwardHeader" => true
             }
           },
+          # The API token or session cookie forwardAuth checked must not
+          # reach websockify, where anyone with root in the sandbox could
+          # read it.
+          "vnc-stripcredentials-#{id}" => {
+            "headers" => {
+              "customRequestHeaders" => { "Authorization" => "", "Cookie" => "" }
+            }
+          },
           "vnc-stripprefix-#{id}" => {
             "stripPrefix" => {
               "prefixes" => [ "/vnc/#{id}" ]
@@ -129,9 +145,6 @@ class VncManager
         }
       }
     }
-
-    File.write(config_path, config.to_yaml)
-    true
   end
 
   def tls_config
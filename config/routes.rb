@@ -123,20 +123,26 @@ Rails.application.routes.draw do
 
   namespace :api do
     get "archived_sandboxes", to: "sandboxes#archived_index"
+    get "events", to: "events#index"
+    get "copies/:id", to: "sandbox_copies#show", as: :copy
     resources :projects, only: [ :index, :show, :create, :destroy ]
     resources :sandboxes do
+      get :lookup, on: :collection
       member do
         post :start
         post :stop
         post :rebuild
         get :logs
         post :connect
+        post :copy, controller: "sandbox_copies", action: "create"
         post :snapshot
         post :restore
         post :archive_restore
         delete :purge
         post :tailscale_connect
         delete :tailscale_disconnect
+        post :vnc
+        post :terminal
         get :gcp_oidc_setup
         patch :gcp_identity
         post "services/:service/start", action: :service_start, as: :service_start
@@ -149,7 +155,9 @@ Rails.application.routes.draw do
     resources :users
     resource :status, only: :show, controller: "status"
     resource :info, only: :show
-    resources :tokens, only: [ :index, :create, :destroy ]
+    resources :tokens, only: [ :index, :create, :destroy ] do
+      post :rotate, on: :member
+    end
     namespace :auth do
       post :device_code
       post :device_token
@@ -0,0 +1,6 @@
+class AddScopeAndDescriptionToApiTokens < ActiveRecord::Migration[8.1]
+  def change
+    add_column :api_tokens, :scope, :string
+    add_column :api_tokens, :description, :string
+  end
+end
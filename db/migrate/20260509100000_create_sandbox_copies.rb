@@ -0,0 +1,17 @@
+class CreateSandboxCopies < ActiveRecord::Migration[8.1]
+  def change
+    create_table :sandbox_copies do |t|
+      t.references :user, null: false, foreign_key: { on_delete: :cascade }
+      t.references :source, null: false, foreign_key: { to_table: :sandboxes, on_delete: :cascade }
+      t.references :destination, null: false, foreign_key: { to_table: :sandboxes, on_delete: :cascade }
+      t.string :source_path, null: false
+      t.string :destination_path, null: false
+      t.boolean :recursive, default: false, null: false
+      t.string :status, default: "pending", null: false
+      t.bigint :bytes, default: 0, null: false
+      t.bigint :total
+      t.text :error
+      t.timestamps
+    end
+  end
+end
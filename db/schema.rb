@@ -10,16 +10,18 @@
 #
 # It's strongly recommended that you check this file into your version control system.
 
-ActiveRecord::Schema[8.1].define(version: 2026_05_07_140000) do
+ActiveRecord::Schema[8.1].define(version: 2026_05_09_100000) do
   # These are extensions that must be enabled in order to support this database
   enable_extension "pg_catalog.plpgsql"
 
   create_table "api_tokens", force: :cascade do |t|
     t.datetime "created_at", null: false
+    t.string "description"
     t.datetime "expires_at"
     t.datetime "last_used_at"
     t.string "name", null: false
     t.string "prefix", null: false
+    t.string "scope"
     t.string "token_digest", null: false
     t.datetime "updated_at", null: false
     t.integer "user_id", null: false
@@ -177,6 +179,24 @@ ActiveRecord::Schema[8.1].define(version: 2026_05_07_140000) do
     t.index ["value"], name: "index_sandbox_aliases_on_value", unique: true, where: "((kind)::text = 'fqdn'::text)"
   end
 
+  create_table "sandbox_copies", force: :cascade do |t|
+    t.bigint "bytes", default: 0, null: false
+    t.datetime "created_at", null: false
+    t.bigint "destination_id", null: false
+    t.string "destination_path", null: false
+    t.text "error"
+    t.boolean "recursive", default: false, null: false
+    t.bigint "source_id", null: false
+    t.string "source_path", null: false
+    t.string "status", default: "pending", null: false
+    t.bigint "total"
+    t.datetime "updated_at", null: false
+    t.bigint "user_id", null: false
+    t.index ["destination_id"], name: "index_sandbox_copies_on_destination_id"
+    t.index ["source_id"], name: "index_sandbox_copies_on_source_id"
+    t.index ["user_id"], name: "index_sandbox_copies_on_user_id"
+  end
+
   create_table "sandbox_mounts", force: :cascade do |t|
     t.string "base_path"
     t.datetime "created_at", null: false
@@ -341,6 +361,9 @@ ActiveRecord::Schema[8.1].define(version: 2026_05_07_140000) do
   add_foreign_key "projects", "users"
   add_foreign_key "routes", "sandboxes"
   add_foreign_key "sandbox_aliases", "sandboxes"
+  add_foreign_key "sandbox_copies", "sandboxes", column: "destination_id", on_delete: :cascade
+  add_foreign_key "sandbox_copies", "sandboxes", column: "source_id", on_delete: :cascade
+  add_foreign_key "sandbox_copies", "users", on_delete: :cascade
   add_foreign_key "sandbox_mounts", "sandboxes"
   add_foreign_key "sandboxes", "gcp_oidc_configs"
   add_foreign_key "sandboxes", "users"
@@ -1,5 +1,7 @@
 #!/bin/bash
 # Wrapper for tmux that preserves SSH agent forwarding across sessions.
+# Attaches to the tmux session named by $1, "main" by default, creating it
+# if needed.
 #
 # Two complementary mechanisms:
 # 1. Symlink: ~/.ssh/agent_sock → current forwarded socket (immediate, always works)
@@ -36,4 +38,4 @@ if command -v ssh-agent-switcher >/dev/null 2>&1; then
 fi
 
 cd ~ 2>/dev/null
-exec tmux new-session -A -s main
+exec tmux new-session -A -s "${1:-main}"
@@ -0,0 +1,115 @@
+require "test_helper"
+
+class Api::SandboxCopiesControllerTest < ActionDispatch::IntegrationTest
+  include ActiveJob::TestHelper
+
+  setup do
+    @user = users(:one)
+    _token, @raw_token = ApiToken.generate_for(@user, name: "test")
+    @headers = { "Authorization" => "Bearer #{@raw_token}" }
+    @source = sandboxes(:alice_running)
+    @destination = @user.sandboxes.create!(
+      name: "otherbox", status: "running", image: SandboxManager::DEFAULT_IMAGE,
+      ssh_port: 2210, container_id: "fedcba654321"
+    )
+  end
+
+  test "starts the copy in a job and answers right away" do
+    assert_enqueued_with(job: SandboxCopyJob) do
+      post "/api/sandboxes/#{@source.id}/copy",
+        params: { to: @destination.id, source_path: "~/data", destination_path: "~/data", recursive: true },
+        headers: @headers
+    end
+
+    assert_response :accepted
+    copy = SandboxCopy.find(response.parsed_body["id"])
+    assert_equal "pending", response.parsed_body["status"]
+    assert_equal @destination, copy.destination
+    assert_equal "~/data", copy.source_path
+    assert copy.recursive
+  end
+
+  test "reports the progress of a copy" do
+    copy = SandboxCopy.create!(
+      user: @user, source: @source, destination: @destination,
+      source_path: "~/data", destination_path: "~/data", status: "running", bytes: 2048, total: 4096
+    )
+
+    get "/api/copies/#{copy.id}", headers: @headers
+
+    assert_response :success
+    assert_equal({ "id" => copy.id, "status" => "running", "bytes" => 2048, "total" => 4096 }, response.parsed_body)
+  end
+
+  test "reports copy errors" do
+    copy = SandboxCopy.create!(
+      user: @user, source: @source, destination: @destination,
+      source_path: "~/missing", destination_path: "~/", status: "failed",
+      error: "devbox: ~/missing: no such file or directory"
+    )
+
+    get "/api/copies/#{copy.id}", headers: @headers
+
+    assert_equal "devbox: ~/missing: no such file or directory", response.parsed_body["error"]
+  end
+
+  test "hides copies of other users" do
+    bob = users(:two)
+    copy = SandboxCopy.create!(
+      user: bob, source: sandboxes(:bob_running), destination: sandboxes(:bob_running),
+      source_path: "~/data", destination_path: "~/copy"
+    )
+
+    get "/api/copies/#{copy.id}", headers: @headers
+
+    assert_response :not_found
+  end
+
+  test "refuses sandboxes of other users" do
+    post "/api/sandboxes/#{@source.id}/copy",
+      params: { to: sandboxes(:bob_running).id, source_path: "~/data", destination_path: "~/data" },
+      headers: @headers
+
+    assert_response :not_found
+  end
+
+  test "a project scoped token copies between the sandboxes of its project" do
+    @source.update!(project_name: "sc")
+    @destination.update!(project_name: "sc")
+    _token, raw = ApiToken.generate_for(@user, name: "ci", scope: "project:sc")
+    headers = { "Authorization" => "Bearer #{raw}" }
+
+    post "/api/sandboxes/#{@source.id}/copy",
+      params: { to: @destination.id, source_path: "~/data", destination_path: "~/data" },
+      headers: headers
+    assert_response :accepted
+
+    get "/api/copies/#{response.parsed_body["id"]}", headers: headers
+    assert_response :success
+  end
+
+  test "a scoped token cannot copy to or watch a copy into a sandbox it does not cover" do
+    _token, raw = ApiToken.generate_for(@user, name: "ci", scope: "sandbox:#{@source.id}")
+    headers = { "Authorization" => "Bearer #{raw}" }
+
+    post "/api/sandboxes/#{@source.id}/copy",
+      params: { to: @destination.id, source_path: "~/data", destination_path: "~/data" },
+      headers: headers
+    assert_response :forbidden
+
+    copy = SandboxCopy.create!(
+      user: @user, source: @source, destination: @destination,
+      source_path: "~/data", destination_path: "~/data"
+    )
+    get "/api/copies/#{copy.id}", headers: headers
+    assert_response :forbidden
+  end
+
+  test "refuses stopped sandboxes" do
+    post "/api/sandboxes/#{@source.id}/copy",
+      params: { to: sandboxes(:alice_stopped).id, source_path: "~/data", destination_path: "~/data" },
+      headers: @headers
+
+    assert_response :conflict
+  end
+end
@@ -72,4 +72,118 @@ class Api::SandboxesControllerTest < ActionDispatch::IntegrationTest
     assert_equal DnsManager.new.hostname_for(sandbox), response.parsed_body["primary_dns_name"]
     assert_equal "10.206.10.9", response.parsed_body["tailscale_ip"]
   end
+
+  test "connect response includes ssh host keys" do
+    sandbox = @user.sandboxes.find_by!(name: "devbox")
+    sandbox.update!(tailscale: true, container_id: "0123456789abcdef0123")
+
+    original_ip = SandboxManager.instance_method(:wait_for_tailscale_ip)
+    original_keys = SandboxManager.instance_method(:ssh_host_keys)
+    SandboxManager.define_method(:wait_for_tailscale_ip) { |sandbox:, **| "10.206.10.9" }
+    SandboxManager.define_method(:ssh_host_keys) { |sandbox:| [ "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHostKey" ] }
+    begin
+      post "/api/sandboxes/#{sandbox.id}/connect", headers: @headers
+    ensure
+      SandboxManager.define_method(:wait_for_tailscale_ip, original_ip)
+      SandboxManager.define_method(:ssh_host_keys, original_keys)
+    end
+
+    assert_response :success
+    assert_equal [ "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHostKey" ], response.parsed_body["host_keys"]
+    assert_equal "0123456789ab", response.parsed_body["container_id"]
+  end
+
+  test "vnc opens the web viewer and returns its url" do
+    sandbox = sandboxes(:alice_running)
+
+    original = VncManager.instance_method(:open)
+    VncManager.define_method(:open) { |sandbox:| vnc_url(sandbox) }
+    begin
+      post "/api/sandboxes/#{sandbox.id}/vnc", headers: @headers
+    ensure
+      VncManager.define_method(:open, original)
+    end
+
+    assert_response :success
+    assert_equal "http://www.example.com/novnc/vnc.html?path=/vnc/#{sandbox.id}/websockify&autoconnect=true",
+      response.parsed_body["url"]
+  end
+
+  test "terminal opens the web terminal and returns the page url" do
+    sandbox = sandboxes(:alice_running)
+
+    opened = nil
+    original = TerminalManager.instance_method(:open)
+    TerminalManager.define_method(:open) { |sandbox:, type: "tmux"| opened = type }
+    begin
+      post "/api/sandboxes/#{sandbox.id}/terminal", params: { type: "shell" }, headers: @headers
+    ensure
+      TerminalManager.define_method(:open, original)
+    end
+
+    assert_response :success
+    assert_equal "shell", opened
+    assert_equal "http://www.example.com/terminals/#{sandbox.id}/shell", response.parsed_body["url"]
+  end
+
+  test "vnc reports a sandbox that is not running" do
+    sandbox = sandboxes(:alice_stopped)
+
+    post "/api/sandboxes/#{sandbox.id}/vnc", headers: @headers
+
+    assert_response :unprocessable_entity
+    assert_equal "Sandbox is not running", response.parsed_body["error"]
+  end
+
+  test "lookup resolves a project scoped ref" do
+    devbox = sandboxes(:alice_running)
+    devbox.update!(project_name: "alpha")
+    other = sandboxes(:alice_stopped)
+    other.update!(name: "devbox", project_name: "beta")
+
+    get "/api/sandboxes/lookup", params: { project: "beta", name: "devbox" }, headers: @headers
+
+    assert_response :success
+    assert_equal other.id, response.parsed_body["id"]
+    assert_equal "beta", response.parsed_body["project_name"]
+  end
+
+  test "lookup reports ambiguous bare names" do
+    sandboxes(:alice_running).update!(project_name: "alpha")
+    sandboxes(:alice_stopped).update!(name: "devbox", project_name: "beta")
+
+    get "/api/sandboxes/lookup", params: { name: "devbox" }, headers: @headers
+
+    assert_response :conflict
+    assert_match "alpha:devbox", response.parsed_body["error"]
+    assert_match "beta:devbox", response.parsed_body["error"]
+  end
+
+  test "lookup does not find other users sandboxes" do
+    get "/api/sandboxes/lookup", params: { name: "workbox" }, headers: @headers
+
+    assert_response :not_found
+  end
+
+  test "start marks the job in progress until the worker finishes" do
+    sandbox = sandboxes(:alice_stopped)
+
+    assert_enqueued_with(job: SandboxStartJob, args: [ { sandbox_id: sandbox.id } ]) do
+      post "/api/sandboxes/#{sandbox.id}/start", headers: @headers
+    end
+
+    assert_response :success
+    assert_equal "starting", response.parsed_body["job_status"]
+  end
+
+  test "start on a running sandbox does not leave the job in progress" do
+    sandbox = sandboxes(:alice_running)
+
+    perform_enqueued_jobs do
+      post "/api/sandboxes/#{sandbox.id}/start", headers: @headers
+    end
+
+    assert_response :success
+    assert_nil sandbox.reload.job_status
+  end
 end
@@ -0,0 +1,69 @@
+require "test_helper"
+
+class Api::TokensControllerTest < ActionDispatch::IntegrationTest
+  setup do
+    @user = users(:one)
+    @sandbox = sandboxes(:alice_running)
+  end
+
+  test "create accepts expiry, scope and description" do
+    post "/api/tokens", params: {
+      email_address: "alice@example.com", password: "password", name: "ci",
+      expires_in: 3600, scope: "sandbox:devbox", description: "deploys devbox"
+    }, as: :json
+
+    assert_response :created
+    body = JSON.parse(response.body)
+    assert_equal "sandbox:#{@sandbox.id}", body["scope"]
+    assert_equal "deploys devbox", body["description"]
+    assert_in_delta 1.hour.from_now, Time.zone.parse(body["expires_at"]), 5.seconds
+  end
+
+  test "create rejects an unknown scope" do
+    post "/api/tokens", params: {
+      email_address: "alice@example.com", password: "password", name: "ci", scope: "everything"
+    }, as: :json
+
+    assert_response :unprocessable_entity
+  end
+
+  test "sandbox scoped token only reaches its sandbox" do
+    _token, raw = ApiToken.generate_for(@user, name: "ci", scope: "sandbox:#{@sandbox.id}")
+    headers = { "Authorization" => "Bearer #{raw}" }
+
+    get "/api/sandboxes", headers: headers
+    assert_response :success
+    assert_equal [ @sandbox.id ], JSON.parse(response.body).map { |s| s["id"] }
+
+    get "/api/sandboxes/#{sandboxes(:alice_stopped).id}", headers: headers
+    assert_response :forbidden
+
+    get "/api/users", headers: headers
+    assert_response :forbidden
+  end
+
+  test "read-only token cannot change anything" do
+    _token, raw = ApiToken.generate_for(@user, name: "ro", scope: "read-only")
+    headers = { "Authorization" => "Bearer #{raw}" }
+
+    get "/api/sandboxes/#{@sandbox.id}", headers: headers
+    assert_response :success
+
+    post "/api/sandboxes/#{@sandbox.id}/stop", headers: headers
+    assert_response :forbidden
+  end
+
+  test "rotate issues a replacement and keeps the old token during the grace period" do
+    token, raw = ApiToken.generate_for(@user, name: "ci")
+
+    post "/api/tokens/#{token.id}/rotate", params: { grace_period: 300 },
+      headers: { "Authorization" => "Bearer #{raw}" }, as: :json
+
+    assert_response :created
+    body = JSON.parse(response.body)
+    assert body["raw_token"].start_with?("sc_")
+    assert_equal token.id, body.dig("replaces", "id")
+    assert_in_delta 5.minutes.from_now, token.reload.expires_at, 5.seconds
+    assert_equal token, ApiToken.authenticate(raw)
+  end
+end
@@ -0,0 +1,58 @@
+require "test_helper"
+
+class VncControllerTest < ActionDispatch::IntegrationTest
+  setup do
+    @admin = users(:one) # alice, admin
+    @user = users(:two) # bob, non-admin
+    @alice_sandbox = sandboxes(:alice_running)
+    @bob_sandbox = sandboxes(:bob_running)
+  end
+
+  # ── auth (forwardAuth endpoint) ────────────────────────────────
+
+  test "auth returns 401 with malformed X-Forwarded-Uri" do
+    get vnc_auth_path, headers: { "X-Forwarded-Uri" => "/some/random/path" }
+    assert_response :unauthorized
+  end
+
+  test "auth returns 200 for sandbox owner" do
+    sign_in_as(@admin)
+    get vnc_auth_path, headers: { "X-Forwarded-Uri" => "/vnc/#{@alice_sandbox.id}/websockify" }
+    assert_response :ok
+  end
+
+  test "auth returns 200 for the owner's api token" do
+    _token, raw = ApiToken.generate_for(@user, name: "cli")
+    get vnc_auth_path, headers: {
+      "X-Forwarded-Uri" => "/vnc/#{@bob_sandbox.id}/websockify",
+      "Authorization" => "Bearer #{raw}"
+    }
+    assert_response :ok
+  end
+
+  test "auth returns 401 for another user's api token" do
+    _token, raw = ApiToken.generate_for(@user, name: "cli")
+    get vnc_auth_path, headers: {
+      "X-Forwarded-Uri" => "/vnc/#{@alice_sandbox.id}/websockify",
+      "Authorization" => "Bearer #{raw}"
+    }
+    assert_response :unauthorized
+  end
+
+  test "auth returns 401 for a read-only api token" do
+    _token, raw = ApiToken.generate_for(@user, name: "cli", scope: "read-only")
+    get vnc_auth_path, headers: {
+      "X-Forwarded-Uri" => "/vnc/#{@bob_sandbox.id}/websockify",
+      "Authorization" => "Bearer #{raw}"
+    }
+    assert_response :unauthorized
+  end
+
+  test "auth returns 401 for an invalid api token without redirecting to login" do
+    get vnc_auth_path, headers: {
+      "X-Forwarded-Uri" => "/vnc/#{@bob_sandbox.id}/websockify",
+      "Authorization" => "Bearer sc_0000_invalid"
+    }
+    assert_response :unauthorized
+  end
+end
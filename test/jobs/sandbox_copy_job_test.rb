@@ -0,0 +1,61 @@
+# frozen_string_literal: true
+
+require "test_helper"
+
+class SandboxCopyJobTest < ActiveJob::TestCase
+  setup do
+    @user = users(:one)
+    @copy = SandboxCopy.create!(
+      user: @user, source: sandboxes(:alice_running), destination: sandboxes(:alice_stopped),
+      source_path: "~/data", destination_path: "~/data", recursive: true
+    )
+    @original = SandboxManager.instance_method(:copy_between)
+  end
+
+  teardown do
+    SandboxManager.define_method(:copy_between, @original)
+  end
+
+  test "records the finished copy" do
+    calls = []
+    SandboxManager.define_method(:copy_between) do |**kwargs, &block|
+      calls << kwargs
+      block&.call(2048, 4096)
+      4096
+    end
+
+    SandboxCopyJob.perform_now(copy_id: @copy.id)
+
+    @copy.reload
+    assert_equal "done", @copy.status
+    assert_equal 4096, @copy.bytes
+    assert_equal 1, calls.size
+    assert_equal "~/data", calls.first[:source_path]
+    assert_equal true, calls.first[:recursive]
+  end
+
+  test "records copy errors" do
+    SandboxManager.define_method(:copy_between) do |**|
+      raise SandboxManager::Error, "devbox: ~/missing: no such file or directory"
+    end
+
+    SandboxCopyJob.perform_now(copy_id: @copy.id)
+
+    @copy.reload
+    assert_equal "failed", @copy.status
+    assert_equal "devbox: ~/missing: no such file or directory", @copy.error
+  end
+
+  test "removes copies that finished long ago" do
+    SandboxManager.define_method(:copy_between) { |**| 0 }
+    old = SandboxCopy.create!(
+      user: @user, source: sandboxes(:alice_running), destination: sandboxes(:alice_stopped),
+      source_path: "~/a", destination_path: "~/a", status: "done", updated_at: 2.days.ago
+    )
+
+    SandboxCopyJob.perform_now(copy_id: @copy.id)
+
+    assert_not SandboxCopy.exists?(old.id)
+    assert SandboxCopy.exists?(@copy.id)
+  end
+end
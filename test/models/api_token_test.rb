@@ -0,0 +1,43 @@
+require "test_helper"
+
+class ApiTokenTest < ActiveSupport::TestCase
+  setup { @user = users(:one) }
+
+  test "resolve_scope stores sandbox refs by id" do
+    sandbox = sandboxes(:alice_running)
+
+    assert_equal "sandbox:#{sandbox.id}", ApiToken.resolve_scope(@user, "sandbox:devbox")
+    assert_equal "sandbox:#{sandbox.id}", ApiToken.resolve_scope(@user, "sandbox:#{sandbox.id}")
+    assert_equal "read-only", ApiToken.resolve_scope(@user, "read-only")
+    assert_nil ApiToken.resolve_scope(@user, "")
+  end
+
+  test "resolve_scope rejects unknown targets" do
+    assert_raises(ApiToken::InvalidScope) { ApiToken.resolve_scope(@user, "sandbox:workbox") }
+    assert_raises(ApiToken::InvalidScope) { ApiToken.resolve_scope(@user, "project:nope") }
+    assert_raises(ApiToken::InvalidScope) { ApiToken.resolve_scope(@user, "admin") }
+  end
+
+  test "restrict narrows sandboxes to the scope" do
+    sandbox = sandboxes(:alice_running)
+    token, _raw = ApiToken.generate_for(@user, name: "ci", scope: "sandbox:#{sandbox.id}")
+
+    assert_equal [ sandbox ], token.restrict(Sandbox.all).to_a
+    assert token.covers_sandbox?(sandbox.id, nil)
+    assert_not token.covers_sandbox?(sandboxes(:alice_stopped).id, nil)
+  end
+
+  test "rotate keeps scope and lets the old token expire after the grace period" do
+    token, _raw = ApiToken.generate_for(@user, name: "ci", scope: "read-only", description: "nightly", expires_in: 30.days)
+
+    freeze_time do
+      replacement, raw = token.rotate!(grace_period: 10.minutes)
+
+      assert_equal replacement, ApiToken.authenticate(raw)
+      assert_equal "read-only", replacement.scope
+      assert_equal "nightly", replacement.description
+      assert_in_delta 30.days.from_now, replacement.expires_at, 1.second
+      assert_equal 10.minutes.from_now, token.reload.expires_at
+    end
+  end
+end
@@ -0,0 +1,42 @@
+require "test_helper"
+
+class SandboxEventTest < ActiveSupport::TestCase
+  include ActionCable::TestHelper
+
+  setup do
+    @sandbox = sandboxes(:alice_running)
+    @channel = SandboxEvent.channel_for(@sandbox.user_id)
+  end
+
+  test "stopping a sandbox publishes a stopped event" do
+    @sandbox.update!(status: "stopped")
+
+    event = ActiveSupport::JSON.decode(broadcasts(@channel).last)
+    assert_equal "stopped", event["type"]
+    assert_equal "running", event["previous_status"]
+    assert_equal @sandbox.id, event.dig("sandbox", "id")
+  end
+
+  test "job lifecycle maps to job events" do
+    @sandbox.update!(job_status: "rebuilding")
+    assert_equal "job_started", SandboxEvent.type_for_update(@sandbox)
+
+    @sandbox.update!(job_status: nil)
+    assert_equal "job_finished", SandboxEvent.type_for_update(@sandbox)
+  end
+
+  test "restoring an archived sandbox is a restored event" do
+    sandbox = sandboxes(:alice_stopped)
+    sandbox.update!(status: "archived")
+    assert_equal "archived", SandboxEvent.type_for_update(sandbox)
+
+    sandbox.update!(status: "stopped")
+    assert_equal "restored", SandboxEvent.type_for_update(sandbox)
+  end
+
+  test "unrelated updates publish nothing" do
+    assert_no_broadcasts(@channel) do
+      @sandbox.update!(vnc_geometry: "1920x1080")
+    end
+  end
+end
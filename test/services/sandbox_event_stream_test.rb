@@ -0,0 +1,69 @@
+require "test_helper"
+require "socket"
+require "timeout"
+
+class SandboxEventStreamTest < ActiveSupport::TestCase
+  setup do
+    @user = users(:one)
+  end
+
+  test "filters events by project and sandbox name" do
+    stream = SandboxEventStream.new(@user.id, project: "sc", sandbox: "dev")
+
+    assert stream.matches?("sandbox" => { "project_name" => "sc", "name" => "dev" })
+    assert_not stream.matches?("sandbox" => { "project_name" => "sc", "name" => "api" })
+    assert_not stream.matches?("sandbox" => { "project_name" => "pool", "name" => "dev" })
+    assert SandboxEventStream.new(@user.id).matches?("sandbox" => { "name" => "anything" })
+  end
+
+  test "a scoped token only receives events for the sandboxes it covers" do
+    sandbox = sandboxes(:alice_running)
+    other = sandboxes(:alice_stopped)
+    token, _raw = ApiToken.generate_for(@user, name: "ci", scope: "sandbox:#{sandbox.id}")
+    stream = SandboxEventStream.new(@user.id, token: token)
+
+    assert stream.matches?(SandboxEvent.payload(sandbox, "started").as_json)
+    assert_not stream.matches?(SandboxEvent.payload(other, "started").as_json)
+
+    project_token, _raw = ApiToken.generate_for(@user, name: "ci", scope: "project:sc")
+    project_stream = SandboxEventStream.new(@user.id, token: project_token)
+    assert project_stream.matches?("sandbox" => { "id" => other.id, "project_name" => "sc" })
+    assert_not project_stream.matches?("sandbox" => { "id" => sandbox.id, "project_name" => "pool" })
+  end
+
+  test "a stream stops counting its token as active once it is revoked or expires" do
+    token, _raw = ApiToken.generate_for(@user, name: "ci")
+    stream = SandboxEventStream.new(@user.id, token: token)
+    assert stream.send(:token_active?)
+
+    token.update!(expires_at: 1.minute.ago)
+    assert_not stream.send(:token_active?)
+
+    token.update!(expires_at: nil)
+    assert stream.send(:token_active?)
+    token.destroy!
+    assert_not stream.send(:token_active?)
+  end
+
+  test "serves the stream on the hijacked connection without blocking the caller" do
+    server, client = UNIXSocket.pair
+    stream = SandboxEventStream.new(@user.id)
+
+    assert stream.serve("rack.hijack" => -> { server })
+
+    received = +""
+    Timeout.timeout(5) { received << client.readpartial(4096) until received.include?(": connected") }
+    assert received.start_with?("HTTP/1.1 200 OK\r\n")
+    assert_includes received, "Content-Type: text/event-stream\r\n"
+  ensure
+    client&.close
+  end
+
+  test "refuses a stream when every slot is taken" do
+    taken = 0
+    taken += 1 while SandboxEventStream.acquire
+    assert_not SandboxEventStream.new(@user.id).serve("rack.hijack" => -> { flunk "hijacked a refused stream" })
+  ensure
+    taken.times { SandboxEventStream.release }
+  end
+end
@@ -467,4 +467,27 @@ class SandboxManagerTest < ActiveSupport::TestCase
 
     assert_equal [ "/data/reconcile/#{@sandbox.id}/work/home:/home/#{@user.name}" ], binds
   end
+
+  test "exec_streaming demultiplexes output without collecting it" do
+    frames = [ [ 1, "D 4 data\n" ], [ 2, "warning" ], [ 1, "x" * 20 ] ]
+    raw = frames.map { |stream, data| [ stream, data.bytesize ].pack("CxxxN") + data }.join
+    connection = Object.new
+    connection.define_singleton_method(:post) do |_path, _query, opts|
+      raw.bytes.each_slice(5) { |slice| opts[:response_block].call(slice.pack("C*"), nil, nil) }
+    end
+    exec = Object.new
+    exec.define_singleton_method(:id) { "exec1" }
+    exec.define_singleton_method(:json) { { "ExitCode" => 3 } }
+    container = Struct.new(:id, :connection).new("c1", connection)
+    original = Docker::Exec.method(:create)
+    Docker::Exec.define_singleton_method(:create) { |*| exec }
+
+    chunks = []
+    status = @manager.exec_streaming(container, [ "true" ], user: @user.name) { |stream, chunk| chunks << [ stream, chunk ] }
+
+    assert_equal 3, status
+    assert_equal [ [ :stdout, "D 4 data\n" ], [ :stderr, "warning" ], [ :stdout, "x" * 20 ] ], chunks
+  ensure
+    Docker::Exec.define_singleton_method(:create, original)
+  end
 end
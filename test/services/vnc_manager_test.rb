@@ -0,0 +1,19 @@
+require "test_helper"
+
+class VncManagerTest < ActiveSupport::TestCase
+  setup do
+    @sandbox = sandboxes(:alice_running)
+  end
+
+  test "credentials checked by forwardAuth are stripped before websockify" do
+    config = VncManager.new.send(:traefik_config, @sandbox)["http"]
+    id = @sandbox.id
+
+    config["routers"].each_value do |router|
+      middlewares = router["middlewares"]
+      assert_operator middlewares.index("vnc-stripcredentials-#{id}"), :>, middlewares.index("vnc-auth-#{id}")
+    end
+    headers = config.dig("middlewares", "vnc-stripcredentials-#{id}", "headers", "customRequestHeaders")
+    assert_equal({ "Authorization" => "", "Cookie" => "" }, headers)
+  end
+end